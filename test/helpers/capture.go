@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/cilium/cilium/test/helpers/remote"
+)
+
+// defaultCaptureMaxBytes bounds a single capture file when a CaptureSpec
+// does not specify MaxBytes, so that long-running commands like `cilium
+// monitor` or `kubectl logs -f` cannot exhaust disk or memory.
+const defaultCaptureMaxBytes = 50 * 1024 * 1024 // 50MiB
+
+// CaptureSpec describes a single diagnostic command to capture to the
+// report directory. A CaptureSpec is streamed directly to a rotating file
+// writer as the command produces output, bounding memory and disk use,
+// rather than buffering the entire output before writing.
+type CaptureSpec struct {
+	Cmd         string        // Command to execute on the node
+	Logfile     string        // Destination filename, relative to the report directory
+	MaxBytes    int64         // Per-file cap before rotation; 0 uses defaultCaptureMaxBytes
+	MaxDuration time.Duration // Upper bound on how long Cmd may run; 0 means no limit
+	Follow      bool          // Cmd is expected to run until canceled (e.g. `cilium monitor`, `kubectl logs -f`)
+	RotateCount int           // Rotated files to keep; 0 uses defaultRotateCount
+}
+
+// captureCommand runs spec.Cmd on node and streams its combined stdout and
+// stderr directly to a rotating file writer at <path>/<spec.Logfile> as the
+// command produces output, bounded by spec.MaxBytes. node.RunStream attaches
+// the rotating writer to the command's live output, so bytes hit disk (and
+// rotate) as they are produced instead of after the command exits.
+func captureCommand(ctx context.Context, path string, spec CaptureSpec, node remote.Node) error {
+	if node == nil {
+		return fmt.Errorf("cannot capture %q: invalid node instance", spec.Cmd)
+	}
+
+	maxBytes := spec.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = defaultCaptureMaxBytes
+	}
+
+	writer, err := newRotatingWriter(filepath.Join(path, spec.Logfile), maxBytes, spec.RotateCount)
+	if err != nil {
+		return fmt.Errorf("cannot create capture file for %q: %w", spec.Cmd, err)
+	}
+	defer writer.Close()
+
+	if spec.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, spec.MaxDuration)
+		defer cancel()
+	}
+
+	err = node.RunStream(ctx, spec.Cmd, writer)
+	if err != nil && spec.Follow && ctx.Err() != nil {
+		// A Follow command (e.g. `cilium monitor`, `kubectl logs -f`) is
+		// expected to run until the caller stops following it; a failure
+		// caused by that cancellation is the normal way to stop, not an
+		// error worth surfacing.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot stream capture for %q: %w", spec.Cmd, err)
+	}
+	return nil
+}
+
+// StartBackgroundCapture begins streaming spec on node in the background,
+// suitable for commands started in a Before-hook and stopped in an
+// After-hook (e.g. `cilium monitor` for the duration of a test). Output is
+// written to the rotating file writer as it is produced, so `.log.1`,
+// `.log.2` rotations appear during the capture rather than only once it
+// stops. It returns a cancel func that stops the capture; callers must call
+// it exactly once, typically via defer.
+func StartBackgroundCapture(ctx context.Context, spec CaptureSpec, node remote.Node) (context.CancelFunc, error) {
+	path, err := CreateReportDirectory()
+	if err != nil {
+		return nil, fmt.Errorf("cannot create report directory for background capture: %w", err)
+	}
+
+	captureCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := captureCommand(captureCtx, path, spec, node); err != nil {
+			log.WithError(err).Errorf("background capture of %q failed", spec.Cmd)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}, nil
+}
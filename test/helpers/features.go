@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/cilium/cilium/test/helpers/features"
+)
+
+// globalFeatures is the process-wide FeatureSet populated from environment
+// variables and, where a live cluster is available, from `cilium-dbg status`
+// output. Tests should prefer Features().Require(...)/Features().Skip(...)
+// over composing RunsOn*/DoesNotRunOn* predicates.
+var globalFeatures = features.NewFeatureSet()
+
+// Features returns the process-wide FeatureSet, after refreshing the
+// features that can be derived from the environment alone.
+//
+// Env vars are re-read on every call, rather than cached after the first
+// read, so that a test which mutates KERNEL/NETNEXT/CILIUM_NO_IPV6_OUTSIDE/
+// the CI integration mid-run is observed like it was by the RunsOn*/
+// DoesNotRunOn* predicates this replaces.
+func Features() *features.FeatureSet {
+	ensureEnvFeatures()
+	return globalFeatures
+}
+
+// ensureEnvFeatures refreshes the environment-derived features (CI
+// integration, kernel version) from their env vars. It does not require a
+// live cluster connection, unlike PopulateFeaturesFromPod, whose
+// cluster-derived features are left untouched here.
+func ensureEnvFeatures() {
+	netNext := os.Getenv("NETNEXT")
+	globalFeatures.Set(features.GKE, GetCurrentIntegration() == CIIntegrationGKE)
+	globalFeatures.Set(features.AKS, GetCurrentIntegration() == CIIntegrationAKS)
+	globalFeatures.Set(features.NetNextKernel, netNext == "true" || netNext == "1" || os.Getenv("KERNEL") == "net-next")
+	globalFeatures.Set(features.Kernel54, os.Getenv("KERNEL") == "54")
+	globalFeatures.Set(features.DualStack, GetCurrentIntegration() == "" || IsIntegration(CIIntegrationKind))
+	globalFeatures.Set(features.IPv6Outside, os.Getenv("CILIUM_NO_IPV6_OUTSIDE") == "")
+	globalFeatures.Set(features.CiliumEndpointSlice, GetCurrentIntegration() == "" || IsIntegration(CIIntegrationKind))
+}
+
+// PopulateFeaturesFromPod queries `cilium-dbg status -o json` on pod and
+// records the kube-proxy-replacement.features.* values into the
+// process-wide FeatureSet, reusing the same status output that
+// HasSocketLB/HasBPFNodePort already parse.
+func (kub *Kubectl) PopulateFeaturesFromPod(pod string) error {
+	res := kub.CiliumExecContext(context.TODO(), pod, "cilium-dbg status -o json")
+	if !res.WasSuccessful() {
+		return fmt.Errorf("cilium-dbg status failed on pod %q: %s", pod, res.OutputPrettyPrint())
+	}
+	return globalFeatures.PopulateFromStatus(res.CombineOutput().Bytes())
+}
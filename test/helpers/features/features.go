@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+// Package features implements a central registry of environment and cluster
+// capabilities ("features") for the test suite, replacing a growing zoo of
+// ad-hoc RunsOn*/DoesNotRunOn* boolean predicates with a single FeatureSet
+// that tests query by name through its Has/Require/Skip methods, e.g.
+// helpers.Features().Require(features.DualStack). The package itself holds
+// no FeatureSet and has no package-level Has, Require or Skip functions.
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/onsi/ginkgo"
+)
+
+// Feature identifies a named boolean capability of the test environment.
+// Names mirror the dotted paths under `kube-proxy-replacement.features` in
+// `cilium-dbg status -o json` where applicable, e.g. "kpr.socket-lb".
+type Feature string
+
+const (
+	// GKE indicates the tests are running against a GKE cluster.
+	GKE Feature = "gke"
+	// AKS indicates the tests are running against an AKS cluster.
+	AKS Feature = "aks"
+	// NetNextKernel indicates the nodes run the net-next/bpf-next kernel.
+	NetNextKernel Feature = "kernel.net-next"
+	// Kernel54 indicates the nodes run the 5.4 kernel.
+	Kernel54 Feature = "kernel.5.4"
+	// DualStack indicates the cluster has IPv4/IPv6 dual-stack enabled.
+	DualStack Feature = "dualstack"
+	// IPv6Outside indicates the environment supports IPv6 connectivity to
+	// the outside world.
+	IPv6Outside Feature = "ipv6-outside"
+	// SocketLB indicates cilium-dbg reports the socket-LB (host-reachable
+	// services) kube-proxy-replacement feature is enabled.
+	SocketLB Feature = "kpr.socket-lb"
+	// NodePort indicates cilium-dbg reports the BPF NodePort
+	// kube-proxy-replacement feature is enabled.
+	NodePort Feature = "kpr.node-port"
+	// CiliumEndpointSlice indicates the CiliumEndpointSlice feature is
+	// enabled for the tested Kubernetes version.
+	CiliumEndpointSlice Feature = "cilium-endpoint-slice"
+)
+
+// kprStatus mirrors the subset of `cilium-dbg status -o json`'s
+// kube-proxy-replacement.features object that FeatureSet cares about.
+type kprStatus struct {
+	KubeProxyReplacement struct {
+		Features struct {
+			SocketLB struct {
+				Enabled bool `json:"enabled"`
+			} `json:"socketLB"`
+			NodePort struct {
+				Enabled bool `json:"enabled"`
+			} `json:"nodePort"`
+		} `json:"features"`
+	} `json:"kube-proxy-replacement"`
+}
+
+// FeatureSet is a cache of environment and cluster capabilities, populated
+// through Set, SetForNode and PopulateFromStatus, that tests query instead of
+// composing boolean helpers like RunsOnGKE() && RunsOn54Kernel(). Values
+// stay as recorded until a later call overwrites them.
+type FeatureSet struct {
+	mu       sync.RWMutex
+	features map[Feature]bool
+	nodes    map[string]map[Feature]bool
+}
+
+// NewFeatureSet returns an empty FeatureSet. Callers populate it via Set and
+// PopulateFromStatus before tests make use of Has/Require/Skip.
+func NewFeatureSet() *FeatureSet {
+	return &FeatureSet{
+		features: make(map[Feature]bool),
+		nodes:    make(map[string]map[Feature]bool),
+	}
+}
+
+// Set records whether f is enabled for the whole cluster.
+func (fs *FeatureSet) Set(f Feature, enabled bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.features[f] = enabled
+}
+
+// Has returns whether f was recorded as enabled. Unknown features are
+// treated as disabled.
+func (fs *FeatureSet) Has(f Feature) bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.features[f]
+}
+
+// SetForNode records whether f is enabled specifically for node, so a
+// per-node probe (e.g. kernel version) only needs to run once per node.
+func (fs *FeatureSet) SetForNode(node string, f Feature, enabled bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.nodes[node] == nil {
+		fs.nodes[node] = make(map[Feature]bool)
+	}
+	fs.nodes[node][f] = enabled
+}
+
+// HasOnNode returns whether f was recorded as enabled for node.
+func (fs *FeatureSet) HasOnNode(node string, f Feature) bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.nodes[node][f]
+}
+
+// PopulateFromStatus parses the kube-proxy-replacement.features.* section of
+// a `cilium-dbg status -o json` payload and records the SocketLB and
+// NodePort features, rather than heuristically inferring them from kernel
+// version.
+func (fs *FeatureSet) PopulateFromStatus(statusJSON []byte) error {
+	var status kprStatus
+	if err := json.Unmarshal(statusJSON, &status); err != nil {
+		return fmt.Errorf("parse cilium-dbg status: %w", err)
+	}
+	fs.Set(SocketLB, status.KubeProxyReplacement.Features.SocketLB.Enabled)
+	fs.Set(NodePort, status.KubeProxyReplacement.Features.NodePort.Enabled)
+	return nil
+}
+
+// Require skips the current Ginkgo spec unless every given feature is
+// enabled.
+func (fs *FeatureSet) Require(feats ...Feature) {
+	for _, f := range feats {
+		if !fs.Has(f) {
+			ginkgo.Skip(fmt.Sprintf("feature %q is not enabled in this environment", f))
+		}
+	}
+}
+
+// Skip skips the current Ginkgo spec if any of the given features is
+// enabled.
+func (fs *FeatureSet) Skip(feats ...Feature) {
+	for _, f := range feats {
+		if fs.Has(f) {
+			ginkgo.Skip(fmt.Sprintf("feature %q is enabled in this environment", f))
+		}
+	}
+}
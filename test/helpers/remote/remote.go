@@ -0,0 +1,297 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+// Package remote provides an ergonomic client for running commands and
+// pushing files to out-of-cluster nodes over SSH. It replaces ad-hoc `scp`
+// shell-outs with a typed client so diagnostics and fixture pushes can run
+// against many nodes in parallel.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Node is a remote host that commands can be run against and files pushed
+// to. It is kept minimal and interface-based so it can be faked in tests
+// instead of requiring a live SSH connection.
+type Node interface {
+	// Run executes cmd and returns its combined stdout/stderr.
+	Run(ctx context.Context, cmd string) ([]byte, error)
+	// RunStream executes cmd, copying its combined stdout/stderr to w as
+	// it is produced.
+	RunStream(ctx context.Context, cmd string, w io.Writer) error
+	// Copy writes the contents of r to remotePath on the node with the
+	// given file mode.
+	Copy(ctx context.Context, r io.Reader, remotePath string, mode os.FileMode) error
+	// WithEnv returns a Node that additionally exports env for every
+	// command it runs.
+	WithEnv(env map[string]string) Node
+	// WithTimeout returns a Node whose commands are bounded by d, unless
+	// the caller's context already carries a shorter deadline.
+	WithTimeout(d time.Duration) Node
+}
+
+// PushFixture reads name from fsys (typically an embed.FS of fixture files
+// declared with a //go:embed directive in the calling package, e.g. kubeadm
+// configs or sysctl snippets) and copies it to remotePath on node with the
+// given mode, without shelling out to scp.
+func PushFixture(ctx context.Context, node Node, fsys fs.FS, name, remotePath string, mode os.FileMode) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return fmt.Errorf("open fixture %q: %w", name, err)
+	}
+	defer f.Close()
+
+	if err := node.Copy(ctx, f, remotePath, mode); err != nil {
+		return fmt.Errorf("push fixture %q to %q: %w", name, remotePath, err)
+	}
+	return nil
+}
+
+// SSHConfig configures dialing a Node over SSH.
+type SSHConfig struct {
+	Host    string
+	Port    int
+	User    string
+	Signer  ssh.Signer
+	Timeout time.Duration
+}
+
+func (cfg SSHConfig) addr() string {
+	port := cfg.Port
+	if port == 0 {
+		port = 22
+	}
+	return fmt.Sprintf("%s:%d", cfg.Host, port)
+}
+
+// connPool caches SSH client connections by address, so repeated NewSSHNode
+// calls for the same host reuse one connection instead of paying a new
+// handshake per command. Entries are evicted as soon as the underlying
+// connection dies (see dial's background watcher) and, defensively, whenever
+// a pooled connection turns out to be stale when a caller tries to use it.
+type connPool struct {
+	mu      sync.Mutex
+	clients map[string]*ssh.Client
+}
+
+var defaultPool = &connPool{clients: make(map[string]*ssh.Client)}
+
+// get returns the pooled client for addr, if any, without dialing.
+func (p *connPool) get(addr string) (*ssh.Client, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c, ok := p.clients[addr]
+	return c, ok
+}
+
+// evict drops addr's pooled client, if c is still the one cached (a redial
+// may have already replaced it), so the next dial call establishes a fresh
+// connection instead of handing back a dead one forever.
+func (p *connPool) evict(addr string, c *ssh.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.clients[addr] == c {
+		delete(p.clients, addr)
+	}
+}
+
+// dial returns the pooled client for cfg's address, dialing and caching a
+// new one if there isn't a live connection cached yet. The blocking
+// ssh.Dial call happens outside the pool lock so that connecting to one
+// host never stalls lookups/dials for other hosts.
+func (p *connPool) dial(cfg SSHConfig) (*ssh.Client, error) {
+	addr := cfg.addr()
+
+	if c, ok := p.get(addr); ok {
+		return c, nil
+	}
+
+	clientCfg := &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(cfg.Signer)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         cfg.Timeout,
+	}
+	c, err := ssh.Dial("tcp", addr, clientCfg)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", addr, err)
+	}
+
+	p.mu.Lock()
+	if existing, ok := p.clients[addr]; ok {
+		// Lost a race with a concurrent dial for the same host; keep the
+		// connection already in the pool and drop the one we just made.
+		p.mu.Unlock()
+		c.Close()
+		return existing, nil
+	}
+	p.clients[addr] = c
+	p.mu.Unlock()
+
+	// Evict the entry as soon as the connection dies (node reboot, idle
+	// timeout, network blip) instead of leaving a dead client cached
+	// forever for the next caller to discover the hard way.
+	go func() {
+		c.Wait()
+		p.evict(addr, c)
+	}()
+
+	return c, nil
+}
+
+// sshNode is the SSH-backed Node implementation, dialing through the
+// process-wide defaultPool.
+type sshNode struct {
+	cfg     SSHConfig
+	env     map[string]string
+	timeout time.Duration
+}
+
+// NewSSHNode returns a Node that runs commands on cfg.Host over SSH, reusing
+// a pooled connection per host.
+func NewSSHNode(cfg SSHConfig) Node {
+	return &sshNode{cfg: cfg}
+}
+
+// newSession opens a session on the pooled connection for n.cfg, redialing
+// once if the pooled connection turns out to be stale: NewSession can fail
+// even on a connection client.Wait hasn't yet noticed is dead, so this is a
+// defensive backstop for the proactive eviction in connPool.dial.
+func (n *sshNode) newSession() (*ssh.Session, error) {
+	client, err := defaultPool.dial(n.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		defaultPool.evict(n.cfg.addr(), client)
+
+		client, err = defaultPool.dial(n.cfg)
+		if err != nil {
+			return nil, err
+		}
+		session, err = client.NewSession()
+		if err != nil {
+			return nil, fmt.Errorf("new ssh session to %s: %w", n.cfg.Host, err)
+		}
+	}
+	return session, nil
+}
+
+// withEnvPrefix prepends shell export statements for n.env ahead of cmd,
+// since the SSH protocol does not forward arbitrary environment variables by
+// default.
+func (n *sshNode) withEnvPrefix(cmd string) string {
+	if len(n.env) == 0 {
+		return cmd
+	}
+	var b strings.Builder
+	for k, v := range n.env {
+		fmt.Fprintf(&b, "export %s=%s; ", k, shellQuote(v))
+	}
+	b.WriteString(cmd)
+	return b.String()
+}
+
+func (n *sshNode) Run(ctx context.Context, cmd string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := n.RunStream(ctx, cmd, &buf)
+	return buf.Bytes(), err
+}
+
+func (n *sshNode) RunStream(ctx context.Context, cmd string, w io.Writer) error {
+	session, err := n.newSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	// golang.org/x/crypto/ssh copies a session's stdout and stderr in two
+	// separate goroutines, so a shared writer must serialize concurrent
+	// Write calls itself; w is not assumed to do so.
+	sw := &syncWriter{w: w}
+	session.Stdout = sw
+	session.Stderr = sw
+
+	if n.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.timeout)
+		defer cancel()
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- session.Run(n.withEnvPrefix(cmd)) }()
+
+	select {
+	case <-ctx.Done():
+		session.Signal(ssh.SIGKILL)
+		return ctx.Err()
+	case err := <-errCh:
+		return err
+	}
+}
+
+func (n *sshNode) Copy(ctx context.Context, r io.Reader, remotePath string, mode os.FileMode) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read source for copy to %s: %w", remotePath, err)
+	}
+
+	session, err := n.newSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	session.Stdin = bytes.NewReader(data)
+	cmd := fmt.Sprintf("install -m %o /dev/stdin %s", mode.Perm(), shellQuote(remotePath))
+	if err := session.Run(n.withEnvPrefix(cmd)); err != nil {
+		return fmt.Errorf("copy to %s:%s: %w", n.cfg.Host, remotePath, err)
+	}
+	return nil
+}
+
+func (n *sshNode) WithEnv(env map[string]string) Node {
+	merged := make(map[string]string, len(n.env)+len(env))
+	for k, v := range n.env {
+		merged[k] = v
+	}
+	for k, v := range env {
+		merged[k] = v
+	}
+	return &sshNode{cfg: n.cfg, env: merged, timeout: n.timeout}
+}
+
+func (n *sshNode) WithTimeout(d time.Duration) Node {
+	return &sshNode{cfg: n.cfg, env: n.env, timeout: d}
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// syncWriter serializes concurrent Write calls to w behind a mutex, so a
+// single writer can be shared between a session's stdout and stderr copy
+// goroutines without interleaving or racing.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/cilium/cilium/test/helpers/remote"
+)
+
+// ReportOnNodesWithoutCilium runs reportCmds on every node returned by
+// GetNodesWithoutCilium in parallel, via errgroup, writing each node's
+// captures to its own subdirectory of path. nodeFor resolves a node name (as
+// returned by GetNodesWithoutCilium) to the remote.Node used to reach it.
+//
+// This replaces shelling a single reportMapContext call out to each
+// out-of-cluster node in sequence.
+func ReportOnNodesWithoutCilium(ctx context.Context, path string, reportCmds map[string]string, nodeFor func(name string) remote.Node) error {
+	nodes := GetNodesWithoutCilium()
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	for _, name := range nodes {
+		g.Go(func() error {
+			return reportOnNode(ctx, path, name, reportCmds, nodeFor)
+		})
+	}
+	return g.Wait()
+}
+
+func reportOnNode(ctx context.Context, path, name string, reportCmds map[string]string, nodeFor func(name string) remote.Node) error {
+	node := nodeFor(name)
+	if node == nil {
+		return fmt.Errorf("no remote.Node available for node %q", name)
+	}
+
+	nodePath := filepath.Join(path, name)
+	if err := os.MkdirAll(nodePath, os.ModePerm); err != nil {
+		return fmt.Errorf("create report directory for node %q: %w", name, err)
+	}
+
+	for cmd, logfile := range reportCmds {
+		out, runErr := node.Run(ctx, cmd)
+		if err := os.WriteFile(filepath.Join(nodePath, logfile), out, LogPerm); err != nil {
+			return fmt.Errorf("write report for %q on node %q: %w", cmd, name, err)
+		}
+		if runErr != nil {
+			log.WithError(runErr).Errorf("command %q failed on node %q", cmd, name)
+		}
+	}
+	return nil
+}
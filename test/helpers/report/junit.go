@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/cilium/cilium/test/helpers"
+)
+
+// JUnitFilename is the name of the JUnit-XML summary written for the
+// containing test suite.
+const JUnitFilename = "junit.xml"
+
+// JUnitTestSuite is the root element of a JUnit-XML report, the format
+// understood by Prow, GitHub Actions and most CI dashboards.
+type JUnitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      float64         `xml:"time,attr"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase represents a single test within a JUnitTestSuite.
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+}
+
+// JUnitFailure holds the failure message and output of a failed
+// JUnitTestCase.
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// NewPassedCase returns a JUnitTestCase that ran for duration without
+// failing.
+func NewPassedCase(name, className string, duration time.Duration) JUnitTestCase {
+	return JUnitTestCase{Name: name, ClassName: className, Time: duration.Seconds()}
+}
+
+// NewFailedCase returns a JUnitTestCase that ran for duration and failed
+// with the given message and output.
+func NewFailedCase(name, className string, duration time.Duration, message, body string) JUnitTestCase {
+	return JUnitTestCase{
+		Name:      name,
+		ClassName: className,
+		Time:      duration.Seconds(),
+		Failure:   &JUnitFailure{Message: message, Body: body},
+	}
+}
+
+// WriteJUnit renders cases into a JUnitTestSuite named suiteName and writes
+// it as JUnitFilename in the current test's report directory.
+func WriteJUnit(suiteName string, cases []JUnitTestCase) error {
+	suite := JUnitTestSuite{
+		Name:      suiteName,
+		Tests:     len(cases),
+		TestCases: cases,
+	}
+	for _, c := range cases {
+		suite.Time += c.Time
+		if c.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal JUnit suite %q: %w", suiteName, err)
+	}
+	data = append([]byte(xml.Header), data...)
+	return helpers.CreateLogFile(JUnitFilename, data)
+}
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+// Package report provides structured, machine-readable test reporting on top
+// of the existing report file writers in the helpers package, so that CI
+// systems such as Prow and GitHub Actions can ingest per-test artifacts and
+// link to them without shell-parsing the report directory layout.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cilium/cilium/test/helpers"
+)
+
+// ManifestFilename is the name of the machine-readable artifact manifest
+// written alongside the other report files in a test's report directory.
+const ManifestFilename = "artifacts.json"
+
+// Artifact describes a single command captured for a test, recording enough
+// detail for a CI system to link the command to its output without having to
+// infer the report directory layout.
+type Artifact struct {
+	Command    string        `json:"command"`
+	ExitCode   int           `json:"exitCode"`
+	Duration   time.Duration `json:"duration"`
+	Stdout     string        `json:"stdout,omitempty"`
+	Stderr     string        `json:"stderr,omitempty"`
+	Node       string        `json:"node,omitempty"`
+	Descriptor string        `json:"descriptor"`
+}
+
+// Manifest is the top-level machine-readable summary of every artifact
+// captured for a single Ginkgo test.
+type Manifest struct {
+	Descriptor string     `json:"descriptor"`
+	Artifacts  []Artifact `json:"artifacts"`
+}
+
+// Reporter wraps the package-level report file helpers (CreateReportDirectory,
+// CreateLogFile, WriteToReportFile) and additionally tracks every artifact
+// written on its behalf, so the accumulated Manifest can be written once the
+// test finishes.
+type Reporter struct {
+	descriptor string
+	artifacts  []Artifact
+}
+
+// NewReporter returns a Reporter for the Ginkgo test identified by
+// descriptor, typically CurrentGinkgoTestDescription().FullTestText.
+func NewReporter(descriptor string) *Reporter {
+	return &Reporter{descriptor: descriptor}
+}
+
+// LogFiles describes the stdout and, optionally, stderr output of a single
+// captured command, to be written to the report directory and recorded as
+// an Artifact by WriteLogFiles/AppendLogFiles. StderrFile is left empty when
+// a command's stdout and stderr were combined into StdoutFile.
+type LogFiles struct {
+	Cmd        string
+	Node       string
+	ExitCode   int
+	Duration   time.Duration
+	StdoutFile string
+	Stdout     []byte
+	StderrFile string
+	Stderr     []byte
+}
+
+// WriteLogFiles writes lf.Stdout to lf.StdoutFile, and lf.Stderr to
+// lf.StderrFile if set, in the current test's report directory via
+// helpers.CreateLogFile, and records both paths as an Artifact.
+func (r *Reporter) WriteLogFiles(lf LogFiles) error {
+	if err := helpers.CreateLogFile(lf.StdoutFile, lf.Stdout); err != nil {
+		return err
+	}
+	if lf.StderrFile != "" {
+		if err := helpers.CreateLogFile(lf.StderrFile, lf.Stderr); err != nil {
+			return err
+		}
+	}
+	r.record(lf)
+	return nil
+}
+
+// AppendLogFiles appends lf.Stdout to lf.StdoutFile, and lf.Stderr to
+// lf.StderrFile if set, in the current test's report directory via
+// helpers.WriteToReportFile, and records both paths as an Artifact.
+func (r *Reporter) AppendLogFiles(lf LogFiles) error {
+	if err := helpers.WriteToReportFile(lf.Stdout, lf.StdoutFile); err != nil {
+		return err
+	}
+	if lf.StderrFile != "" {
+		if err := helpers.WriteToReportFile(lf.Stderr, lf.StderrFile); err != nil {
+			return err
+		}
+	}
+	r.record(lf)
+	return nil
+}
+
+func (r *Reporter) record(lf LogFiles) {
+	r.artifacts = append(r.artifacts, Artifact{
+		Command:    lf.Cmd,
+		ExitCode:   lf.ExitCode,
+		Duration:   lf.Duration,
+		Stdout:     lf.StdoutFile,
+		Stderr:     lf.StderrFile,
+		Node:       lf.Node,
+		Descriptor: r.descriptor,
+	})
+}
+
+// Artifacts returns the artifacts recorded so far, in the order they were
+// written.
+func (r *Reporter) Artifacts() []Artifact {
+	return r.artifacts
+}
+
+// WriteManifest writes the accumulated Manifest as indented JSON to
+// ManifestFilename in the current test's report directory.
+func (r *Reporter) WriteManifest() error {
+	manifest := Manifest{
+		Descriptor: r.descriptor,
+		Artifacts:  r.artifacts,
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal artifact manifest: %w", err)
+	}
+	return helpers.CreateLogFile(ManifestFilename, data)
+}
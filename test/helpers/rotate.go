@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// defaultRotateCount is how many rotated files a rotatingWriter keeps around
+// when a CaptureSpec does not specify RotateCount.
+const defaultRotateCount = 3
+
+// rotatingWriter is an io.Writer that appends to a file on disk, rotating it
+// (lumberjack-style: file, file.1, file.2, ...) once it grows past maxBytes,
+// so long-running captures (e.g. `cilium monitor`) cannot grow without
+// bound. It also keeps a bounded ring buffer of the most recently written
+// bytes so callers can inspect the tail without re-reading the file.
+//
+// Write, Close and Tail are safe for concurrent use: captureCommand hands a
+// rotatingWriter to node.RunStream, whose stdout and stderr are copied by
+// two separate goroutines.
+type rotatingWriter struct {
+	path        string
+	maxBytes    int64
+	rotateCount int
+
+	mu      sync.Mutex
+	f       *os.File
+	written int64
+	tail    []byte
+	maxTail int
+}
+
+// newRotatingWriter opens (creating if necessary) path for appending, bounded
+// to maxBytes per file with up to rotateCount rotated files kept. A maxBytes
+// of 0 disables rotation: the writer simply grows the file without limit,
+// matching the previous reportMapContext behavior. A rotateCount of 0 with
+// maxBytes > 0 keeps defaultRotateCount rotated files rather than disabling
+// rotation.
+func newRotatingWriter(path string, maxBytes int64, rotateCount int) (*rotatingWriter, error) {
+	if rotateCount == 0 && maxBytes > 0 {
+		rotateCount = defaultRotateCount
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, LogPerm)
+	if err != nil {
+		return nil, fmt.Errorf("open capture file %q: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("stat capture file %q: %w", path, err)
+	}
+	return &rotatingWriter{
+		path:        path,
+		maxBytes:    maxBytes,
+		rotateCount: rotateCount,
+		f:           f,
+		written:     info.Size(),
+		maxTail:     64 * 1024,
+	}, nil
+}
+
+// Write appends p to the underlying file, rotating beforehand if the write
+// would exceed maxBytes, and records p in the tail ring buffer. Write is
+// safe for concurrent use.
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxBytes > 0 && w.written+int64(len(p)) > w.maxBytes {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.f.Write(p)
+	w.written += int64(n)
+	w.appendTail(p[:n])
+	return n, err
+}
+
+// Tail returns the most recently written bytes, bounded to maxTail.
+func (w *rotatingWriter) Tail() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.tail
+}
+
+// Close flushes and closes the underlying file.
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f.Close()
+}
+
+func (w *rotatingWriter) appendTail(p []byte) {
+	w.tail = append(w.tail, p...)
+	if len(w.tail) > w.maxTail {
+		w.tail = w.tail[len(w.tail)-w.maxTail:]
+	}
+}
+
+// rotate closes the current file, shifts path.N -> path.N+1 up to
+// rotateCount, and reopens path as an empty file.
+func (w *rotatingWriter) rotate() error {
+	if err := w.f.Close(); err != nil {
+		return fmt.Errorf("close capture file %q for rotation: %w", w.path, err)
+	}
+
+	for i := w.rotateCount - 1; i >= 1; i-- {
+		src := rotatedPath(w.path, i)
+		dst := rotatedPath(w.path, i+1)
+		if _, err := os.Stat(src); err == nil {
+			if err := os.Rename(src, dst); err != nil {
+				return fmt.Errorf("rotate %q to %q: %w", src, dst, err)
+			}
+		}
+	}
+	if err := os.Rename(w.path, rotatedPath(w.path, 1)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rotate %q: %w", w.path, err)
+	}
+
+	f, err := os.OpenFile(w.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, LogPerm)
+	if err != nil {
+		return fmt.Errorf("reopen capture file %q after rotation: %w", w.path, err)
+	}
+	w.f = f
+	w.written = 0
+	return nil
+}
+
+func rotatedPath(path string, generation int) string {
+	return filepath.Join(filepath.Dir(path), fmt.Sprintf("%s.%d", filepath.Base(path), generation))
+}
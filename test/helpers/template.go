@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/yaml"
+)
+
+// renderConfig holds the options collected from a RenderTemplateWithData
+// call's RenderOption arguments.
+type renderConfig struct {
+	baseDir string
+}
+
+// RenderOption configures RenderTemplateWithData.
+type RenderOption func(*renderConfig)
+
+// WithBaseDir resolves {{ include "file" . }} calls relative to dir, which
+// should be the manifest tree root that ManifestGet already walks. This lets
+// integration- and k8s-version-specific manifest overlays share partials
+// instead of duplicating them per K8s version directory.
+func WithBaseDir(dir string) RenderOption {
+	return func(c *renderConfig) {
+		c.baseDir = dir
+	}
+}
+
+// RenderTemplateWithData renders tmplt as a text/template (manifests aren't
+// HTML, unlike RenderTemplate) against data, with a curated, Helm/Sprig-style
+// funcmap: env, envDefault, include, indent, toYaml, hasKey, default and
+// quote. Use WithBaseDir so {{ include "cilium-cm.yaml" . }} resolves
+// relative to the manifest tree.
+func RenderTemplateWithData(tmplt string, data any, opts ...RenderOption) (*bytes.Buffer, error) {
+	cfg := &renderConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	t, err := template.New("").Funcs(templateFuncMap(cfg)).Parse(tmplt)
+	if err != nil {
+		return nil, err
+	}
+
+	content := new(bytes.Buffer)
+	if err := t.Execute(content, data); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// templateFuncMap returns the funcmap shared by RenderTemplateWithData and
+// its own {{ include }} calls, bound to cfg so included templates resolve
+// relative to the same base directory as their parent.
+func templateFuncMap(cfg *renderConfig) template.FuncMap {
+	return template.FuncMap{
+		"env": os.Getenv,
+		"envDefault": func(key, def string) string {
+			if v := os.Getenv(key); v != "" {
+				return v
+			}
+			return def
+		},
+		"include": func(name string, data any) (string, error) {
+			path := name
+			if cfg.baseDir != "" {
+				path = filepath.Join(cfg.baseDir, name)
+			}
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("include %q: %w", name, err)
+			}
+			buf, err := RenderTemplateWithData(string(raw), data, WithBaseDir(cfg.baseDir))
+			if err != nil {
+				return "", fmt.Errorf("include %q: %w", name, err)
+			}
+			return buf.String(), nil
+		},
+		"indent": func(spaces int, s string) string {
+			pad := strings.Repeat(" ", spaces)
+			lines := strings.Split(s, "\n")
+			for i, line := range lines {
+				lines[i] = pad + line
+			}
+			return strings.Join(lines, "\n")
+		},
+		"toYaml": func(v any) (string, error) {
+			out, err := yaml.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSuffix(string(out), "\n"), nil
+		},
+		"hasKey": func(m map[string]any, key string) bool {
+			_, ok := m[key]
+			return ok
+		},
+		"default": func(def, val any) any {
+			if val == nil || val == "" {
+				return def
+			}
+			return val
+		},
+		"quote": func(s string) string {
+			return fmt.Sprintf("%q", s)
+		},
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"math"
 	"math/rand/v2"
 	"os"
 	"path/filepath"
@@ -22,6 +23,8 @@ import (
 
 	"github.com/cilium/cilium/test/config"
 	ginkgoext "github.com/cilium/cilium/test/ginkgo-ext"
+	"github.com/cilium/cilium/test/helpers/features"
+	"github.com/cilium/cilium/test/helpers/remote"
 )
 
 // Sleep sleeps for the specified duration in seconds
@@ -51,8 +54,9 @@ func RenderTemplate(tmplt string) (*bytes.Buffer, error) {
 
 // TimeoutConfig represents the configuration for the timeout of a command.
 type TimeoutConfig struct {
-	Ticker  time.Duration // Check interval
-	Timeout time.Duration // Limit for how long to spend in the command
+	Ticker  time.Duration  // Check interval
+	Timeout time.Duration  // Limit for how long to spend in the command
+	Backoff *BackoffConfig // Optional capped exponential backoff between checks
 }
 
 // Validate ensuires that the parameters for the TimeoutConfig are reasonable
@@ -66,9 +70,72 @@ func (c *TimeoutConfig) Validate() error {
 	} else if c.Ticker < time.Second {
 		return fmt.Errorf("Timeout config Ticker interval too short (must be at least 1 second): %v", c.Ticker)
 	}
+	if c.Backoff != nil {
+		if err := c.Backoff.Validate(); err != nil {
+			return err
+		}
+		if c.Backoff.MaxElapsedTime > 0 {
+			if c.Backoff.MaxElapsedTime < 5*time.Second {
+				return fmt.Errorf("Backoff MaxElapsedTime too short (must be at least 5 seconds): %v", c.Backoff.MaxElapsedTime)
+			}
+			c.Timeout = c.Backoff.MaxElapsedTime
+		}
+	}
 	return nil
 }
 
+// BackoffConfig configures capped exponential backoff with full jitter
+// between retries of RepeatUntilTrue and WithTimeout (via
+// TimeoutConfig.Backoff), WithBackoff and WithContextBackoff. Without a
+// BackoffConfig, RepeatUntilTrue/WithTimeout retry at the fixed
+// TimeoutConfig.Ticker interval and WithContext at its fixed freq interval;
+// a BackoffConfig{Multiplier: 1} is equivalent to that fixed-interval
+// behavior, so call-sites can migrate incrementally.
+//
+// The delay before retry N (0-indexed) is calculated as
+// `min(MaxInterval, InitialInterval * Multiplier^N)`, then scaled by
+// JitterFraction: a JitterFraction of 1 is "full jitter" (the delay is
+// uniformly random in [0, interval]), 0 disables jitter entirely. See
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+type BackoffConfig struct {
+	InitialInterval time.Duration // Delay before the first retry
+	MaxInterval     time.Duration // Upper bound for any single delay
+	Multiplier      float64       // Growth factor applied per retry; <= 1 means fixed InitialInterval
+	JitterFraction  float64       // Fraction of the computed delay to randomize, in [0, 1]
+	MaxElapsedTime  time.Duration // Overall deadline across all retries; 0 defers to TimeoutConfig.Timeout or the caller's context
+}
+
+// Validate fills in defaults for unset fields and ensures BackoffConfig's
+// parameters are usable.
+func (b *BackoffConfig) Validate() error {
+	if b.InitialInterval == 0 {
+		b.InitialInterval = time.Second
+	}
+	if b.MaxInterval == 0 {
+		b.MaxInterval = b.InitialInterval
+	}
+	if b.Multiplier < 1 {
+		b.Multiplier = 1
+	}
+	if b.JitterFraction < 0 || b.JitterFraction > 1 {
+		return fmt.Errorf("backoff jitter fraction must be in [0, 1]: %v", b.JitterFraction)
+	}
+	return nil
+}
+
+// next returns the delay to wait before the given retry attempt (0-indexed),
+// applying the configured multiplier, cap and jitter.
+func (b *BackoffConfig) next(attempt int) time.Duration {
+	interval := float64(b.InitialInterval) * math.Pow(b.Multiplier, float64(attempt))
+	if max := float64(b.MaxInterval); interval > max {
+		interval = max
+	}
+	if b.JitterFraction > 0 {
+		interval *= 1 - b.JitterFraction + b.JitterFraction*rand.Float64()
+	}
+	return time.Duration(interval)
+}
+
 // WithTimeout executes body using the time interval specified in config until
 // the timeout in config is reached. Returns an error if the timeout is
 // exceeded for body to execute successfully.
@@ -87,8 +154,22 @@ func RepeatUntilTrueDefaultTimeout(body func() bool) error {
 	return RepeatUntilTrue(body, &TimeoutConfig{Timeout: HelperTimeout})
 }
 
+// WithBackoff executes body with capped exponential backoff and jitter
+// between retries, as described by backoff, until it returns true or
+// backoff.MaxElapsedTime elapses. It returns an error wrapping msg if the
+// deadline is exceeded.
+func WithBackoff(body func() bool, msg string, backoff BackoffConfig) error {
+	timeout := backoff.MaxElapsedTime
+	if timeout == 0 {
+		timeout = HelperTimeout
+	}
+	return WithTimeout(body, msg, &TimeoutConfig{Timeout: timeout, Backoff: &backoff})
+}
+
 // RepeatUntilTrue repeatedly calls body until body returns true or the timeout
-// expires
+// expires. If config.Backoff is set, the wait between retries grows with
+// capped exponential backoff and jitter instead of the fixed config.Ticker
+// interval.
 func RepeatUntilTrue(body func() bool, config *TimeoutConfig) error {
 	if err := config.Validate(); err != nil {
 		return err
@@ -108,8 +189,7 @@ func RepeatUntilTrue(body func() bool, config *TimeoutConfig) error {
 	go asyncBody(bodyChan)
 
 	done := time.After(config.Timeout)
-	ticker := time.NewTicker(config.Ticker)
-	defer ticker.Stop()
+	attempt := 0
 	for {
 		select {
 		case success := <-bodyChan:
@@ -118,7 +198,12 @@ func RepeatUntilTrue(body func() bool, config *TimeoutConfig) error {
 			}
 			// Provide some form of rate-limiting here before running next
 			// execution in case body() returns at a fast rate.
-			<-ticker.C
+			if config.Backoff != nil {
+				time.Sleep(config.Backoff.next(attempt))
+				attempt++
+			} else {
+				time.Sleep(config.Ticker)
+			}
 			go asyncBody(bodyChan)
 		case <-done:
 			return fmt.Errorf("%s timeout expired", config.Timeout)
@@ -158,6 +243,50 @@ func WithContext(ctx context.Context, f func(ctx context.Context) (bool, error),
 	}
 }
 
+// WithContextBackoff is the WithContext equivalent of WithBackoff: it
+// executes f with capped exponential backoff and jitter between calls,
+// instead of the fixed freq interval, until f returns true, f returns an
+// error, ctx is canceled, or backoff.MaxElapsedTime (if set) elapses.
+func WithContextBackoff(ctx context.Context, f func(ctx context.Context) (bool, error), backoff BackoffConfig) error {
+	if err := backoff.Validate(); err != nil {
+		return err
+	}
+	if backoff.MaxElapsedTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, backoff.MaxElapsedTime)
+		defer cancel()
+	}
+
+	attempt := 0
+	for {
+		timer := time.NewTimer(backoff.next(attempt))
+		attempt++
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+			stop, err := f(ctx)
+			if err != nil {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				default:
+					return err
+				}
+			}
+			if stop {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
 // GetAppPods fetches app pod names for a namespace.
 // For Http based tests, we identify pods with format id=<pod_name>, while
 // for Kafka based tests, we identify pods with the format app=<pod_name>.
@@ -264,8 +393,8 @@ func WriteToReportFile(data []byte, filename string) error {
 
 // reportMap saves the output of the given commands to the specified filename.
 // Function needs a directory path where the files are going to be written and
-// a *SSHMeta instance to execute the commands
-func reportMap(path string, reportCmds map[string]string, node *SSHMeta) {
+// a remote.Node instance to execute the commands
+func reportMap(path string, reportCmds map[string]string, node remote.Node) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	reportMapContext(ctx, path, reportCmds, node)
@@ -273,20 +402,22 @@ func reportMap(path string, reportCmds map[string]string, node *SSHMeta) {
 
 // reportMap saves the output of the given commands to the specified filename.
 // Function needs a directory path where the files are going to be written and
-// a *SSHMeta instance to execute the commands
-func reportMapContext(ctx context.Context, path string, reportCmds map[string]string, node *SSHMeta) {
+// a remote.Node instance to execute the commands.
+//
+// Each command is streamed to a size-bounded, rotating file writer as it
+// runs, rather than buffered in full before being written to disk; see
+// CaptureSpec and StartBackgroundCapture for capturing long-running or
+// following commands (e.g. `cilium monitor`) outside of this synchronous,
+// one-shot helper.
+func reportMapContext(ctx context.Context, path string, reportCmds map[string]string, node remote.Node) {
 	if node == nil {
 		log.Errorf("cannot execute reportMap due invalid node instance")
 		return
 	}
 
 	for cmd, logfile := range reportCmds {
-		res := node.ExecContext(ctx, cmd, ExecOptions{SkipLog: true})
-		err := os.WriteFile(
-			fmt.Sprintf("%s/%s", path, logfile),
-			res.CombineOutput().Bytes(),
-			LogPerm)
-		if err != nil {
+		spec := CaptureSpec{Cmd: cmd, Logfile: logfile}
+		if err := captureCommand(ctx, path, spec, node); err != nil {
 			log.WithError(err).Errorf("cannot create test results for command '%s'", cmd)
 		}
 	}
@@ -382,13 +513,11 @@ func failIfContainsBadLogMsg(logs, label string, blacklist map[string][]string)
 // RunsOnNetNextKernel checks whether a test case is running on the net-next
 // kernel (depending on the image, it's the latest kernel either from net-next.git
 // or bpf-next.git tree).
+//
+// This is a thin wrapper delegating to the features registry; see
+// helpers/features.
 func RunsOnNetNextKernel() bool {
-	netNext := os.Getenv("NETNEXT")
-	if netNext == "true" || netNext == "1" {
-		return true
-	}
-	netNext = os.Getenv("KERNEL")
-	return netNext == "net-next"
+	return Features().Has(features.NetNextKernel)
 }
 
 // DoesNotRunOnNetNextKernel is the complement function of RunsOnNetNextKernel.
@@ -397,8 +526,11 @@ func DoesNotRunOnNetNextKernel() bool {
 }
 
 // RunsOn54Kernel checks whether a test case is running on the 5.4 kernel.
+//
+// This is a thin wrapper delegating to the features registry; see
+// helpers/features.
 func RunsOn54Kernel() bool {
-	return os.Getenv("KERNEL") == "54"
+	return Features().Has(features.Kernel54)
 }
 
 // DoesNotRunOn54Kernel is the complement function of RunsOn54Kernel.
@@ -421,8 +553,11 @@ func DoesNotRunOn54OrLaterKernel() bool {
 }
 
 // RunsOnGKE returns true if the tests are running on GKE.
+//
+// This is a thin wrapper delegating to the features registry; see
+// helpers/features.
 func RunsOnGKE() bool {
-	return GetCurrentIntegration() == CIIntegrationGKE
+	return Features().Has(features.GKE)
 }
 
 // DoesNotRunOnGKE is the complement function of DoesNotRunOnGKE.
@@ -431,8 +566,11 @@ func DoesNotRunOnGKE() bool {
 }
 
 // RunsOnAKS returns true if the tests are running on AKS.
+//
+// This is a thin wrapper delegating to the features registry; see
+// helpers/features.
 func RunsOnAKS() bool {
-	return GetCurrentIntegration() == CIIntegrationAKS
+	return Features().Has(features.AKS)
 }
 
 // DoesNotRunOnAKS is the complement function of DoesNotRunOnAKS.
@@ -548,14 +686,16 @@ func SkipRaceDetectorEnabled() bool {
 
 // DualStackSupported returns whether the current environment has DualStack IPv6
 // enabled or not for the cluster.
+//
+// This is a thin wrapper delegating to the features registry; see
+// helpers/features.
 func DualStackSupported() bool {
 	// AKS does not support dual stack yet
 	if IsIntegration(CIIntegrationAKS) {
 		return false
 	}
 
-	// We only have DualStack enabled in KIND.
-	return GetCurrentIntegration() == "" || IsIntegration(CIIntegrationKind)
+	return Features().Has(features.DualStack)
 }
 
 // DualStackSupportBeta returns true if the environment has a Kubernetes version that
@@ -571,12 +711,18 @@ func DualStackSupportBeta() bool {
 
 // CiliumEndpointSliceFeatureEnabled returns true only if the environment has a kubernetes version
 // greater than or equal to 1.21.
+//
+// This is a thin wrapper delegating to the features registry; see
+// helpers/features.
 func CiliumEndpointSliceFeatureEnabled() bool {
-	return GetCurrentIntegration() == "" || IsIntegration(CIIntegrationKind)
+	return Features().Has(features.CiliumEndpointSlice)
 }
 
 // SupportIPv6ToOutside returns true if the CI environment supports IPv6
 // connectivity to the outside world.
+//
+// This is a thin wrapper delegating to the features registry; see
+// helpers/features.
 func SupportIPv6ToOutside() bool {
-	return os.Getenv("CILIUM_NO_IPV6_OUTSIDE") == ""
+	return Features().Has(features.IPv6Outside)
 }